@@ -0,0 +1,356 @@
+// Package internal_gengogrpc generates the gRPC service stubs that
+// protoc-gen-go-grpc writes out: a server interface, a client interface and
+// implementation, and a RegisterXxxServer function, for every service
+// declared in a .proto file. It is deliberately kept separate from
+// internal_gengo, which only ever emits message and enum types, mirroring
+// the split that the upstream protoc-gen-go-grpc plugin introduced.
+package internal_gengogrpc
+
+import (
+	"fmt"
+
+	gengo "github.com/whiteCcinn/protobuf-go/cmd/protoc-gen-go/internal_gengo"
+	"github.com/whiteCcinn/protobuf-go/compiler/protogen"
+)
+
+// GenerateVersionMarkers controls whether GenerateFile emits a
+// "// versions:" header block. Its initial value is copied from
+// internal_gengo's GenerateVersionMarkers when the package is initialized;
+// later changes to either variable do not affect the other.
+var GenerateVersionMarkers = gengo.GenerateVersionMarkers
+
+const (
+	contextPackage = protogen.GoImportPath("context")
+	grpcPackage    = protogen.GoImportPath("google.golang.org/grpc")
+)
+
+// GenerateFile generates the server interface, client stub, and
+// RegisterXxxServer function for every service in file, writing them to a
+// "<prefix>_grpc.pb.go" file. It returns nil without creating a file if file
+// declares no services.
+func GenerateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
+	if len(file.Services) == 0 {
+		return nil
+	}
+	filename := file.GeneratedFilenamePrefix + "_grpc.pb.go"
+	g := gen.NewGeneratedFile(filename, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-grpc. DO NOT EDIT.")
+	if GenerateVersionMarkers {
+		g.P("// versions:")
+		g.P("// - protoc-gen-go-grpc")
+	}
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, service := range file.Services {
+		genService(g, service)
+	}
+	return g
+}
+
+func genService(g *protogen.GeneratedFile, service *protogen.Service) {
+	serverType := service.GoName + "Server"
+	clientType := service.GoName + "Client"
+	clientImpl := unexport(service.GoName) + "Client"
+
+	// Server interface.
+	g.P("// ", serverType, " is the server API for ", service.GoName, " service.")
+	g.P("type ", serverType, " interface {")
+	for _, method := range service.Methods {
+		g.P(serverMethodSignature(g, service, method))
+	}
+	g.P("}")
+	g.P()
+
+	// Client interface and implementation.
+	g.P("// ", clientType, " is the client API for ", service.GoName, " service.")
+	g.P("type ", clientType, " interface {")
+	for _, method := range service.Methods {
+		g.P(clientMethodSignature(g, service, method))
+	}
+	g.P("}")
+	g.P()
+	g.P("type ", clientImpl, " struct {")
+	g.P("cc ", grpcPackage.Ident("ClientConnInterface"))
+	g.P("}")
+	g.P()
+	g.P("func New", clientType, "(cc ", grpcPackage.Ident("ClientConnInterface"), ") ", clientType, " {")
+	g.P("return &", clientImpl, "{cc}")
+	g.P("}")
+	g.P()
+
+	var methodDescs, streamDescs []string
+	for _, method := range service.Methods {
+		switch {
+		case method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer():
+			genClientStreamMethod(g, service, clientImpl, method, len(streamDescs))
+			genServerStreamHandler(g, service, method)
+			streamDescs = append(streamDescs, streamDescEntry(service, method))
+		default:
+			genClientUnaryMethod(g, service, clientImpl, method)
+			genUnaryHandler(g, service, method)
+			methodDescs = append(methodDescs, fmt.Sprintf("{MethodName: %q, Handler: %s}", method.Desc.Name(), handlerName(service, method)))
+		}
+	}
+
+	// Registration helper.
+	g.P("func Register", service.GoName, "Server(s ", grpcPackage.Ident("ServiceRegistrar"), ", srv ", serverType, ") {")
+	g.P("s.RegisterService(&", service.GoName, "_ServiceDesc, srv)")
+	g.P("}")
+	g.P()
+
+	g.P("var ", service.GoName, "_ServiceDesc = ", grpcPackage.Ident("ServiceDesc"), "{")
+	g.P("ServiceName: ", fmt.Sprintf("%q", service.Desc.FullName()), ",")
+	g.P("HandlerType: (*", serverType, ")(nil),")
+	g.P("Methods: []", grpcPackage.Ident("MethodDesc"), "{")
+	for _, m := range methodDescs {
+		g.P(m, ",")
+	}
+	g.P("},")
+	g.P("Streams: []", grpcPackage.Ident("StreamDesc"), "{")
+	for _, s := range streamDescs {
+		g.P(s, ",")
+	}
+	g.P("},")
+	g.P("Metadata: ", fmt.Sprintf("%q", service.Desc.ParentFile().Path()), ",")
+	g.P("}")
+	g.P()
+}
+
+// serverMethodSignature returns the server interface method signature for
+// method, which differs between unary and streaming RPCs.
+func serverMethodSignature(g *protogen.GeneratedFile, service *protogen.Service, method *protogen.Method) string {
+	if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+		return fmt.Sprintf("%s(%s, *%s) (*%s, error)",
+			method.GoName,
+			g.QualifiedGoIdent(contextPackage.Ident("Context")),
+			g.QualifiedGoIdent(method.Input.GoIdent),
+			g.QualifiedGoIdent(method.Output.GoIdent),
+		)
+	}
+	if method.Desc.IsStreamingServer() && !method.Desc.IsStreamingClient() {
+		return fmt.Sprintf("%s(*%s, %s) error", method.GoName, g.QualifiedGoIdent(method.Input.GoIdent), streamServerType(service, method))
+	}
+	return fmt.Sprintf("%s(%s) error", method.GoName, streamServerType(service, method))
+}
+
+// clientMethodSignature returns the client interface method signature for
+// method, which differs between unary and streaming RPCs.
+func clientMethodSignature(g *protogen.GeneratedFile, service *protogen.Service, method *protogen.Method) string {
+	if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+		return fmt.Sprintf("%s(ctx %s, in *%s, opts ...%s) (*%s, error)",
+			method.GoName,
+			g.QualifiedGoIdent(contextPackage.Ident("Context")),
+			g.QualifiedGoIdent(method.Input.GoIdent),
+			g.QualifiedGoIdent(grpcPackage.Ident("CallOption")),
+			g.QualifiedGoIdent(method.Output.GoIdent),
+		)
+	}
+	if method.Desc.IsStreamingServer() && !method.Desc.IsStreamingClient() {
+		return fmt.Sprintf("%s(ctx %s, in *%s, opts ...%s) (%s, error)",
+			method.GoName,
+			g.QualifiedGoIdent(contextPackage.Ident("Context")),
+			g.QualifiedGoIdent(method.Input.GoIdent),
+			g.QualifiedGoIdent(grpcPackage.Ident("CallOption")),
+			streamClientType(service, method),
+		)
+	}
+	return fmt.Sprintf("%s(ctx %s, opts ...%s) (%s, error)",
+		method.GoName,
+		g.QualifiedGoIdent(contextPackage.Ident("Context")),
+		g.QualifiedGoIdent(grpcPackage.Ident("CallOption")),
+		streamClientType(service, method),
+	)
+}
+
+func genClientUnaryMethod(g *protogen.GeneratedFile, service *protogen.Service, clientImpl string, method *protogen.Method) {
+	g.P("func (c *", clientImpl, ") ", method.GoName, "(ctx ", contextPackage.Ident("Context"), ", in *", g.QualifiedGoIdent(method.Input.GoIdent), ", opts ...", grpcPackage.Ident("CallOption"), ") (*", g.QualifiedGoIdent(method.Output.GoIdent), ", error) {")
+	g.P("out := new(", g.QualifiedGoIdent(method.Output.GoIdent), ")")
+	g.P(fmt.Sprintf("err := c.cc.Invoke(ctx, %q, in, out, opts...)", fullMethodName(service, method)))
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return out, nil")
+	g.P("}")
+	g.P()
+}
+
+// genClientStreamMethod generates the client-side stream wrapper type and
+// the XxxClient constructor method for a streaming RPC. streamIndex is this
+// method's position within the service's Streams slice, i.e. the number of
+// streaming methods declared before it.
+func genClientStreamMethod(g *protogen.GeneratedFile, service *protogen.Service, clientImpl string, method *protogen.Method, streamIndex int) {
+	streamType := streamClientType(service, method)
+	streamImpl := unexport(streamType)
+
+	if method.Desc.IsStreamingServer() && !method.Desc.IsStreamingClient() {
+		g.P("func (c *", clientImpl, ") ", method.GoName, "(ctx ", contextPackage.Ident("Context"), ", in *", g.QualifiedGoIdent(method.Input.GoIdent), ", opts ...", grpcPackage.Ident("CallOption"), ") (", streamType, ", error) {")
+	} else {
+		g.P("func (c *", clientImpl, ") ", method.GoName, "(ctx ", contextPackage.Ident("Context"), ", opts ...", grpcPackage.Ident("CallOption"), ") (", streamType, ", error) {")
+	}
+	g.P(fmt.Sprintf("stream, err := c.cc.NewStream(ctx, &%s_ServiceDesc.Streams[%d], %q, opts...)", service.GoName, streamIndex, fullMethodName(service, method)))
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("x := &", streamImpl, "{stream}")
+	if method.Desc.IsStreamingServer() && !method.Desc.IsStreamingClient() {
+		g.P("if err := x.ClientStream.SendMsg(in); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("if err := x.ClientStream.CloseSend(); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+	}
+	g.P("return x, nil")
+	g.P("}")
+	g.P()
+
+	g.P("type ", streamType, " interface {")
+	if method.Desc.IsStreamingClient() {
+		g.P("Send(*", g.QualifiedGoIdent(method.Input.GoIdent), ") error")
+	}
+	if method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+		g.P("CloseAndRecv() (*", g.QualifiedGoIdent(method.Output.GoIdent), ", error)")
+	} else {
+		g.P("Recv() (*", g.QualifiedGoIdent(method.Output.GoIdent), ", error)")
+	}
+	g.P(grpcPackage.Ident("ClientStream"))
+	g.P("}")
+	g.P()
+	g.P("type ", streamImpl, " struct {")
+	g.P(grpcPackage.Ident("ClientStream"))
+	g.P("}")
+	g.P()
+	if method.Desc.IsStreamingClient() {
+		g.P("func (x *", streamImpl, ") Send(m *", g.QualifiedGoIdent(method.Input.GoIdent), ") error {")
+		g.P("return x.ClientStream.SendMsg(m)")
+		g.P("}")
+		g.P()
+	}
+	if method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+		g.P("func (x *", streamImpl, ") CloseAndRecv() (*", g.QualifiedGoIdent(method.Output.GoIdent), ", error) {")
+		g.P("if err := x.ClientStream.CloseSend(); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+	} else {
+		g.P("func (x *", streamImpl, ") Recv() (*", g.QualifiedGoIdent(method.Output.GoIdent), ", error) {")
+	}
+	g.P("m := new(", g.QualifiedGoIdent(method.Output.GoIdent), ")")
+	g.P("if err := x.ClientStream.RecvMsg(m); err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return m, nil")
+	g.P("}")
+	g.P()
+}
+
+func genUnaryHandler(g *protogen.GeneratedFile, service *protogen.Service, method *protogen.Method) {
+	g.P("func ", handlerName(service, method), "(srv interface{}, ctx ", contextPackage.Ident("Context"), ", dec func(interface{}) error, interceptor ", grpcPackage.Ident("UnaryServerInterceptor"), ") (interface{}, error) {")
+	g.P("in := new(", g.QualifiedGoIdent(method.Input.GoIdent), ")")
+	g.P("if err := dec(in); err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("if interceptor == nil {")
+	g.P("return srv.(", service.GoName, "Server).", method.GoName, "(ctx, in)")
+	g.P("}")
+	g.P("info := &", grpcPackage.Ident("UnaryServerInfo"), "{")
+	g.P("Server:     srv,")
+	g.P(fmt.Sprintf("FullMethod: %q,", fullMethodName(service, method)))
+	g.P("}")
+	g.P("handler := func(ctx ", contextPackage.Ident("Context"), ", req interface{}) (interface{}, error) {")
+	g.P("return srv.(", service.GoName, "Server).", method.GoName, "(ctx, req.(*", g.QualifiedGoIdent(method.Input.GoIdent), "))")
+	g.P("}")
+	g.P("return interceptor(ctx, in, info, handler)")
+	g.P("}")
+	g.P()
+}
+
+// genServerStreamHandler generates the server-side stream wrapper type and
+// the _Foo_MethodName_Handler StreamHandler for a streaming RPC.
+func genServerStreamHandler(g *protogen.GeneratedFile, service *protogen.Service, method *protogen.Method) {
+	streamType := streamServerType(service, method)
+	streamImpl := unexport(streamType)
+
+	g.P("type ", streamType, " interface {")
+	if method.Desc.IsStreamingServer() {
+		g.P("Send(*", g.QualifiedGoIdent(method.Output.GoIdent), ") error")
+	}
+	if method.Desc.IsStreamingClient() {
+		g.P("Recv() (*", g.QualifiedGoIdent(method.Input.GoIdent), ", error)")
+	}
+	if method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+		g.P("SendAndClose(*", g.QualifiedGoIdent(method.Output.GoIdent), ") error")
+	}
+	g.P(grpcPackage.Ident("ServerStream"))
+	g.P("}")
+	g.P()
+	g.P("type ", streamImpl, " struct {")
+	g.P(grpcPackage.Ident("ServerStream"))
+	g.P("}")
+	g.P()
+	if method.Desc.IsStreamingServer() {
+		g.P("func (x *", streamImpl, ") Send(m *", g.QualifiedGoIdent(method.Output.GoIdent), ") error {")
+		g.P("return x.ServerStream.SendMsg(m)")
+		g.P("}")
+		g.P()
+	}
+	if method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+		g.P("func (x *", streamImpl, ") SendAndClose(m *", g.QualifiedGoIdent(method.Output.GoIdent), ") error {")
+		g.P("return x.ServerStream.SendMsg(m)")
+		g.P("}")
+		g.P()
+	}
+	if method.Desc.IsStreamingClient() {
+		g.P("func (x *", streamImpl, ") Recv() (*", g.QualifiedGoIdent(method.Input.GoIdent), ", error) {")
+		g.P("m := new(", g.QualifiedGoIdent(method.Input.GoIdent), ")")
+		g.P("if err := x.ServerStream.RecvMsg(m); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return m, nil")
+		g.P("}")
+		g.P()
+	}
+
+	g.P("func ", handlerName(service, method), "(srv interface{}, stream ", grpcPackage.Ident("ServerStream"), ") error {")
+	if !method.Desc.IsStreamingClient() {
+		g.P("m := new(", g.QualifiedGoIdent(method.Input.GoIdent), ")")
+		g.P("if err := stream.RecvMsg(m); err != nil {")
+		g.P("return err")
+		g.P("}")
+		g.P("return srv.(", service.GoName, "Server).", method.GoName, "(m, &", streamImpl, "{stream})")
+	} else {
+		g.P("return srv.(", service.GoName, "Server).", method.GoName, "(&", streamImpl, "{stream})")
+	}
+	g.P("}")
+	g.P()
+}
+
+func streamDescEntry(service *protogen.Service, method *protogen.Method) string {
+	return fmt.Sprintf("{StreamName: %q, Handler: %s, ServerStreams: %t, ClientStreams: %t}",
+		method.Desc.Name(), handlerName(service, method), method.Desc.IsStreamingServer(), method.Desc.IsStreamingClient())
+}
+
+func streamServerType(service *protogen.Service, method *protogen.Method) string {
+	return service.GoName + "_" + method.GoName + "Server"
+}
+
+func streamClientType(service *protogen.Service, method *protogen.Method) string {
+	return service.GoName + "_" + method.GoName + "Client"
+}
+
+func handlerName(service *protogen.Service, method *protogen.Method) string {
+	return fmt.Sprintf("_%s_%s_Handler", service.GoName, method.GoName)
+}
+
+func fullMethodName(service *protogen.Service, method *protogen.Method) string {
+	return fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name())
+}
+
+func unexport(s string) string {
+	if s == "" {
+		return s
+	}
+	return string(s[0]|' ') + s[1:]
+}
@@ -0,0 +1,90 @@
+package internal_gengogrpc_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	gengogrpc "github.com/whiteCcinn/protobuf-go/cmd/protoc-gen-go-grpc/internal_gengogrpc"
+	"github.com/whiteCcinn/protobuf-go/compiler/protogen"
+	"github.com/whiteCcinn/protobuf-go/types/descriptorpb"
+	"github.com/whiteCcinn/protobuf-go/types/pluginpb"
+)
+
+// method builds a MethodDescriptorProto for the RPC name that takes and
+// returns test.Msg, optionally marked as a
+// client- and/or server-streaming RPC.
+func method(name string, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+	return &descriptorpb.MethodDescriptorProto{
+		Name:            proto.String(name),
+		InputType:       proto.String(".test.Msg"),
+		OutputType:      proto.String(".test.Msg"),
+		ClientStreaming: proto.Bool(clientStreaming),
+		ServerStreaming: proto.Bool(serverStreaming),
+	}
+}
+
+// TestMultiStreamServiceBindsDistinctStreamDescs is a regression test: a
+// service with more than one streaming RPC must bind each client stub to its
+// own position in ServiceDesc.Streams, not always to Streams[0].
+func TestMultiStreamServiceBindsDistinctStreamDescs(t *testing.T) {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("multi_stream.proto"),
+		Syntax:  proto.String("proto3"),
+		Package: proto.String("test"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Msg"),
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Svc"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Unary", false, false),
+				method("Download", false, true),
+				method("Chat", true, true),
+			},
+		}},
+		Options: &descriptorpb.FileOptions{GoPackage: proto.String("test")},
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{fd},
+		FileToGenerate: []string{fd.GetName()},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatalf("protogen.Options{}.New() = %v", err)
+	}
+	var file *protogen.File
+	for _, f := range gen.Files {
+		if f.Generate {
+			file = f
+		}
+	}
+	if file == nil {
+		t.Fatal("no generated file in request")
+	}
+
+	g := gengogrpc.GenerateFile(gen, file)
+	if g == nil {
+		t.Fatal("GenerateFile returned nil for a file with services")
+	}
+	content, err := g.Content()
+	if err != nil {
+		t.Fatalf("g.Content() = %v", err)
+	}
+
+	streamRe := regexp.MustCompile(`func \(c \*svcClient\) (\w+)\([^\n]*\n\tstream, err := c\.cc\.NewStream\(ctx, &Svc_ServiceDesc\.Streams\[(\d+)\]`)
+	matches := streamRe.FindAllStringSubmatch(string(content), -1)
+	want := map[string]string{
+		"Download": "0",
+		"Chat":     "1",
+	}
+	if len(matches) != len(want) {
+		t.Fatalf("found %d streaming client methods, want %d; content:\n%s", len(matches), len(want), content)
+	}
+	for _, m := range matches {
+		method, idx := m[1], m[2]
+		if want[method] != idx {
+			t.Errorf("method %s bound to Streams[%s], want Streams[%s]", method, idx, want[method])
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// protoc-gen-go-grpc is a plugin for the Google protocol buffer compiler to
+// generate Go gRPC service stubs. Install it by building this program and
+// making it accessible within your PATH with the name:
+//   protoc-gen-go-grpc
+//
+// The 'go-grpc' suffix becomes part of the argument for the protocol
+// compiler, such that it can be invoked as:
+//   protoc --go-grpc_out=paths=source_relative:. path/to/file.proto
+//
+// This generates Go service stubs for the protocol buffer defined by
+// file.proto. With that input, the output will be written to:
+//   path/to/file_grpc.pb.go
+package main
+
+import (
+	"flag"
+
+	gengogrpc "github.com/whiteCcinn/protobuf-go/cmd/protoc-gen-go-grpc/internal_gengogrpc"
+	"github.com/whiteCcinn/protobuf-go/compiler/protogen"
+)
+
+func main() {
+	var flags flag.FlagSet
+	protogen.Options{ParamFunc: flags.Set}.Run(func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if f.Generate {
+				gengogrpc.GenerateFile(gen, f)
+			}
+		}
+		return nil
+	})
+}
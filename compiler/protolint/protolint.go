@@ -0,0 +1,59 @@
+// Package protolint reports style violations found while walking the
+// protogen.File values handed to a protoc-gen-go plugin. It is meant to be
+// wired into a generator so that `go generate` fails loudly when a .proto
+// file drifts from house style, rather than relying on a separate CI lint
+// step that can fall out of sync with what was actually compiled.
+package protolint
+
+import (
+	"fmt"
+
+	"github.com/whiteCcinn/protobuf-go/compiler/protogen"
+)
+
+// Diagnostic is a single style violation found in a .proto file.
+type Diagnostic struct {
+	// Filename is the path of the .proto file the violation was found in,
+	// as it appears in the FileDescriptorProto.
+	Filename string
+	// Rule is the name of the Rule that reported the violation.
+	Rule string
+	// Message describes the violation in a form suitable for printing
+	// next to the offending file.
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Filename, d.Rule, d.Message)
+}
+
+// Rule checks a single .proto file for one category of style violation.
+type Rule interface {
+	// Name identifies the rule in reported Diagnostics (e.g. "field-casing").
+	Name() string
+	// Check reports every violation of the rule found in f.
+	Check(f *protogen.File) []Diagnostic
+}
+
+// Linter runs a set of Rules over protogen.File values and collects their
+// Diagnostics.
+type Linter struct {
+	Rules []Rule
+}
+
+// New returns a Linter that runs the given rules.
+func New(rules ...Rule) *Linter {
+	return &Linter{Rules: rules}
+}
+
+// Lint runs every rule in l against each file and returns the concatenation
+// of all reported Diagnostics, in rule order.
+func (l *Linter) Lint(files []*protogen.File) []Diagnostic {
+	var diags []Diagnostic
+	for _, rule := range l.Rules {
+		for _, f := range files {
+			diags = append(diags, rule.Check(f)...)
+		}
+	}
+	return diags
+}
@@ -0,0 +1,143 @@
+package protolint_test
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/whiteCcinn/protobuf-go/compiler/protogen"
+	"github.com/whiteCcinn/protobuf-go/compiler/protolint"
+	"github.com/whiteCcinn/protobuf-go/types/descriptorpb"
+	"github.com/whiteCcinn/protobuf-go/types/pluginpb"
+)
+
+// newTestFile builds a protogen.File for a .proto file that violates every
+// rule in protolint.DefaultRules(), so a single pass can confirm each rule
+// actually fires.
+func newTestFile(t *testing.T) *protogen.File {
+	t.Helper()
+
+	dep := func(name string) *descriptorpb.FileDescriptorProto {
+		return &descriptorpb.FileDescriptorProto{
+			Name:    proto.String(name),
+			Syntax:  proto.String("proto3"),
+			Package: proto.String("test"),
+		}
+	}
+
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("BadFile.proto"),
+		Syntax:     proto.String("proto3"),
+		Package:    proto.String("test"),
+		Dependency: []string{"z.proto", "a.proto"}, // unsorted
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("badMessage"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("someField"),
+				Number: proto.Int32(1),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}},
+		}},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{{
+				Name:   proto.String("Active"),
+				Number: proto.Int32(0),
+			}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Svc"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("doIt"),
+				InputType:  proto.String(".test.badMessage"),
+				OutputType: proto.String(".test.badMessage"),
+			}},
+		}},
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{dep("a.proto"), dep("z.proto"), fd},
+		FileToGenerate: []string{fd.GetName()},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatalf("protogen.Options{}.New() = %v", err)
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			return f
+		}
+	}
+	t.Fatal("no generated file in request")
+	return nil
+}
+
+func TestDefaultRulesCatchEveryViolation(t *testing.T) {
+	f := newTestFile(t)
+	diags := protolint.New(protolint.DefaultRules()...).Lint([]*protogen.File{f})
+
+	want := []string{
+		"filename-casing",
+		"message-casing",
+		"field-casing",
+		"enum-value-casing",
+		"rpc-casing",
+		"import-order",
+		"enum-zero-value-suffix",
+	}
+	got := make(map[string]bool)
+	for _, d := range diags {
+		got[d.Rule] = true
+	}
+	for _, rule := range want {
+		if !got[rule] {
+			t.Errorf("rule %q did not report a diagnostic for the deliberately bad input; diagnostics: %v", rule, diags)
+		}
+	}
+}
+
+func TestDefaultRulesAllowCleanFile(t *testing.T) {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("good_file.proto"),
+		Syntax:  proto.String("proto3"),
+		Package: proto.String("test"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("GoodMessage"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("some_field"),
+				Number: proto.Int32(1),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}},
+		}},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Status"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{{
+				Name:   proto.String("STATUS_UNSPECIFIED"),
+				Number: proto.Int32(0),
+			}},
+		}},
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		ProtoFile:      []*descriptorpb.FileDescriptorProto{fd},
+		FileToGenerate: []string{fd.GetName()},
+	}
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatalf("protogen.Options{}.New() = %v", err)
+	}
+	var f *protogen.File
+	for _, gf := range gen.Files {
+		if gf.Generate {
+			f = gf
+		}
+	}
+	if f == nil {
+		t.Fatal("no generated file in request")
+	}
+
+	diags := protolint.New(protolint.DefaultRules()...).Lint([]*protogen.File{f})
+	if len(diags) != 0 {
+		t.Errorf("Lint(good_file.proto) = %v, want no diagnostics", diags)
+	}
+}
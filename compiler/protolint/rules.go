@@ -0,0 +1,204 @@
+package protolint
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/whiteCcinn/protobuf-go/compiler/protogen"
+)
+
+// DefaultRules returns the standard rule set, which generate-alias runs when
+// its -lint flag is set:
+// filename casing, message/field/enum/RPC casing, import ordering, and the
+// "_UNSPECIFIED" zero-value convention.
+func DefaultRules() []Rule {
+	return []Rule{
+		snakeCaseFilenameRule{},
+		pascalCaseMessageRule{},
+		snakeCaseFieldRule{},
+		screamingSnakeEnumValueRule{},
+		pascalCaseRPCRule{},
+		sortedImportsRule{},
+		enumZeroValueSuffixRule{},
+	}
+}
+
+var (
+	snakeCaseRe      = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	pascalCaseRe     = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	screamingSnakeRe = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+)
+
+type snakeCaseFilenameRule struct{}
+
+func (snakeCaseFilenameRule) Name() string { return "filename-casing" }
+
+func (r snakeCaseFilenameRule) Check(f *protogen.File) []Diagnostic {
+	base := path.Base(f.Desc.Path())
+	name := strings.TrimSuffix(base, ".proto")
+	if !strings.HasSuffix(base, ".proto") || !snakeCaseRe.MatchString(name) {
+		return []Diagnostic{{
+			Filename: f.Desc.Path(),
+			Rule:     r.Name(),
+			Message:  fmt.Sprintf("filename %q is not snake_case.proto", base),
+		}}
+	}
+	return nil
+}
+
+type pascalCaseMessageRule struct{}
+
+func (pascalCaseMessageRule) Name() string { return "message-casing" }
+
+func (r pascalCaseMessageRule) Check(f *protogen.File) []Diagnostic {
+	var diags []Diagnostic
+	var walk func(msgs []*protogen.Message)
+	walk = func(msgs []*protogen.Message) {
+		for _, m := range msgs {
+			name := string(m.Desc.Name())
+			if !pascalCaseRe.MatchString(name) {
+				diags = append(diags, Diagnostic{
+					Filename: f.Desc.Path(),
+					Rule:     r.Name(),
+					Message:  fmt.Sprintf("message %q is not PascalCase", name),
+				})
+			}
+			walk(m.Messages)
+		}
+	}
+	walk(f.Messages)
+	return diags
+}
+
+type snakeCaseFieldRule struct{}
+
+func (snakeCaseFieldRule) Name() string { return "field-casing" }
+
+func (r snakeCaseFieldRule) Check(f *protogen.File) []Diagnostic {
+	var diags []Diagnostic
+	var walk func(msgs []*protogen.Message)
+	walk = func(msgs []*protogen.Message) {
+		for _, m := range msgs {
+			for _, fld := range m.Fields {
+				name := string(fld.Desc.Name())
+				if !snakeCaseRe.MatchString(name) {
+					diags = append(diags, Diagnostic{
+						Filename: f.Desc.Path(),
+						Rule:     r.Name(),
+						Message:  fmt.Sprintf("field %q of message %q is not snake_case", name, m.Desc.Name()),
+					})
+				}
+			}
+			walk(m.Messages)
+		}
+	}
+	walk(f.Messages)
+	return diags
+}
+
+type screamingSnakeEnumValueRule struct{}
+
+func (screamingSnakeEnumValueRule) Name() string { return "enum-value-casing" }
+
+func (r screamingSnakeEnumValueRule) Check(f *protogen.File) []Diagnostic {
+	var diags []Diagnostic
+	check := func(enums []*protogen.Enum) {
+		for _, e := range enums {
+			for _, v := range e.Values {
+				name := string(v.Desc.Name())
+				if !screamingSnakeRe.MatchString(name) {
+					diags = append(diags, Diagnostic{
+						Filename: f.Desc.Path(),
+						Rule:     r.Name(),
+						Message:  fmt.Sprintf("enum value %q of enum %q is not SCREAMING_SNAKE_CASE", name, e.Desc.Name()),
+					})
+				}
+			}
+		}
+	}
+	check(f.Enums)
+	var walk func(msgs []*protogen.Message)
+	walk = func(msgs []*protogen.Message) {
+		for _, m := range msgs {
+			check(m.Enums)
+			walk(m.Messages)
+		}
+	}
+	walk(f.Messages)
+	return diags
+}
+
+type pascalCaseRPCRule struct{}
+
+func (pascalCaseRPCRule) Name() string { return "rpc-casing" }
+
+func (r pascalCaseRPCRule) Check(f *protogen.File) []Diagnostic {
+	var diags []Diagnostic
+	for _, svc := range f.Services {
+		for _, m := range svc.Methods {
+			name := string(m.Desc.Name())
+			if !pascalCaseRe.MatchString(name) {
+				diags = append(diags, Diagnostic{
+					Filename: f.Desc.Path(),
+					Rule:     r.Name(),
+					Message:  fmt.Sprintf("rpc %q of service %q is not PascalCase", name, svc.Desc.Name()),
+				})
+			}
+		}
+	}
+	return diags
+}
+
+type sortedImportsRule struct{}
+
+func (sortedImportsRule) Name() string { return "import-order" }
+
+func (r sortedImportsRule) Check(f *protogen.File) []Diagnostic {
+	deps := f.Proto.GetDependency()
+	if sort.StringsAreSorted(deps) {
+		return nil
+	}
+	return []Diagnostic{{
+		Filename: f.Desc.Path(),
+		Rule:     r.Name(),
+		Message:  "imports are not sorted",
+	}}
+}
+
+type enumZeroValueSuffixRule struct{}
+
+func (enumZeroValueSuffixRule) Name() string { return "enum-zero-value-suffix" }
+
+func (r enumZeroValueSuffixRule) Check(f *protogen.File) []Diagnostic {
+	var diags []Diagnostic
+	check := func(enums []*protogen.Enum) {
+		for _, e := range enums {
+			for _, v := range e.Values {
+				if v.Desc.Number() != 0 {
+					continue
+				}
+				name := string(v.Desc.Name())
+				if !strings.HasSuffix(name, "_UNSPECIFIED") {
+					diags = append(diags, Diagnostic{
+						Filename: f.Desc.Path(),
+						Rule:     r.Name(),
+						Message:  fmt.Sprintf("zero value %q of enum %q should be suffixed _UNSPECIFIED", name, e.Desc.Name()),
+					})
+				}
+			}
+		}
+	}
+	check(f.Enums)
+	var walk func(msgs []*protogen.Message)
+	walk = func(msgs []*protogen.Message) {
+		for _, m := range msgs {
+			check(m.Enums)
+			walk(m.Messages)
+		}
+	}
+	walk(f.Messages)
+	return diags
+}
@@ -13,12 +13,14 @@ package descriptor
 import (
 	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io/ioutil"
 	"sync"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/whiteCcinn/protobuf-go/reflect/protodesc"
 	"github.com/whiteCcinn/protobuf-go/reflect/protoreflect"
+	"github.com/whiteCcinn/protobuf-go/reflect/protoregistry"
 	"github.com/whiteCcinn/protobuf-go/runtime/protoimpl"
 
 	descriptorpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
@@ -69,9 +71,18 @@ func deriveRawDescriptor(d protoreflect.Descriptor) ([]byte, []int) {
 		idxs = append(idxs, d.Index())
 		d = d.Parent()
 		if d == nil {
-			// TODO: We could construct a FileDescriptor stub for standalone
-			// descriptors to satisfy the API.
-			return nil, nil
+			// The descriptor has no parent file, e.g. because it was
+			// dynamically constructed. Synthesize a FileDescriptor stub
+			// containing just the original leaf declaration so that callers
+			// still get a usable raw descriptor.
+			file, idxs := synthesizeRawDescriptor(origDesc)
+			if file == nil {
+				return nil, nil
+			}
+			if v, ok := rawDescCache.LoadOrStore(origDesc, &rawDesc{file, idxs}); ok {
+				return v.(*rawDesc).fileDesc, v.(*rawDesc).indexes
+			}
+			return file, idxs
 		}
 		if _, ok := d.(protoreflect.FileDescriptor); ok {
 			break
@@ -94,6 +105,34 @@ func deriveRawDescriptor(d protoreflect.Descriptor) ([]byte, []int) {
 	return file, idxs
 }
 
+// synthesizeRawDescriptor constructs a minimal FileDescriptorProto containing
+// only d (a standalone message or enum with no parent file, e.g. one
+// produced by dynamicpb or a type registered directly into
+// protoregistry.GlobalTypes), GZIP-marshals it, and returns it along with the
+// index path ([]int{0}) needed to find d within it.
+func synthesizeRawDescriptor(d protoreflect.Descriptor) ([]byte, []int) {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:   proto.String("synthetic/" + string(d.FullName()) + ".proto"),
+		Syntax: proto.String("proto3"),
+	}
+	if pkg := d.FullName().Parent(); pkg != "" {
+		fd.Package = proto.String(string(pkg))
+	}
+	switch d := d.(type) {
+	case protoreflect.MessageDescriptor:
+		fd.MessageType = []*descriptorpb.DescriptorProto{protodesc.ToDescriptorProto(d)}
+	case protoreflect.EnumDescriptor:
+		fd.EnumType = []*descriptorpb.EnumDescriptorProto{protodesc.ToEnumDescriptorProto(d)}
+	default:
+		return nil, nil
+	}
+	b, err := proto.Marshal(fd)
+	if err != nil {
+		return nil, nil
+	}
+	return protoimpl.X.CompressGZIP(b), []int{0}
+}
+
 // EnumRawDescriptor returns the GZIP'd raw file descriptor representing
 // the enum and the index path to reach the enum declaration.
 // The returned slices must not be mutated.
@@ -143,6 +182,35 @@ func deriveFileDescriptor(rawDesc []byte) *descriptorpb.FileDescriptorProto {
 	return fd
 }
 
+// deriveFileDescriptorChecked is deriveFileDescriptor for callers that cannot
+// assume rawDesc is a well-formed, trusted GZIP'd FileDescriptorProto, e.g.
+// because it arrived over the wire. Unlike deriveFileDescriptor, it reports
+// malformed input as an error instead of panicking.
+func deriveFileDescriptorChecked(rawDesc []byte) (*descriptorpb.FileDescriptorProto, error) {
+	if len(rawDesc) == 0 {
+		return nil, fmt.Errorf("descriptor: empty raw descriptor")
+	}
+	if v, ok := fileDescCache.Load(&rawDesc[0]); ok {
+		return v.(*descriptorpb.FileDescriptorProto), nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(rawDesc))
+	if err != nil {
+		return nil, fmt.Errorf("descriptor: invalid gzip: %v", err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("descriptor: invalid gzip: %v", err)
+	}
+	fd := new(descriptorpb.FileDescriptorProto)
+	if err := proto.Unmarshal(b, fd); err != nil {
+		return nil, fmt.Errorf("descriptor: invalid file descriptor: %v", err)
+	}
+	if v, ok := fileDescCache.LoadOrStore(&rawDesc[0], fd); ok {
+		return v.(*descriptorpb.FileDescriptorProto), nil
+	}
+	return fd, nil
+}
+
 // EnumDescriptorProto returns the file descriptor proto representing
 // the enum and the enum descriptor proto for the enum itself.
 // The returned proto messages must not be mutated.
@@ -178,3 +246,104 @@ func MessageDescriptorProto(m proto.GeneratedMessage) (*descriptorpb.FileDescrip
 	}
 	return fd, md
 }
+
+// EnumFromRawDescriptor returns the protoreflect.EnumType registered in
+// protoregistry.GlobalTypes for the enum described by the GZIP'd file
+// descriptor and index path, the inverse of EnumRawDescriptor. It is
+// intended for tooling that receives a raw descriptor over the wire (e.g.
+// gRPC server reflection) and wants to instantiate the enum's Go type
+// without re-parsing every field by hand.
+func EnumFromRawDescriptor(rawDesc []byte, idxs []int) (protoreflect.EnumType, error) {
+	fd, err := deriveFileDescriptorChecked(rawDesc)
+	if err != nil {
+		return nil, err
+	}
+	name, err := enumFullName(fd, idxs)
+	if err != nil {
+		return nil, err
+	}
+	return protoregistry.GlobalTypes.FindEnumByName(name)
+}
+
+// MessageFromRawDescriptor returns the protoreflect.MessageType registered in
+// protoregistry.GlobalTypes for the message described by the GZIP'd file
+// descriptor and index path, the inverse of MessageRawDescriptor. It is
+// intended for tooling that receives a raw descriptor over the wire (e.g.
+// gRPC server reflection) and wants to instantiate the message's Go type
+// without re-parsing every field by hand.
+func MessageFromRawDescriptor(rawDesc []byte, idxs []int) (protoreflect.MessageType, error) {
+	fd, err := deriveFileDescriptorChecked(rawDesc)
+	if err != nil {
+		return nil, err
+	}
+	name, err := messageFullName(fd, idxs)
+	if err != nil {
+		return nil, err
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+// inBounds reports whether i is a valid index into a slice of length n. Raw
+// descriptors and their index paths may come from the wire, so every walk
+// over them must check the lower bound as well as the upper one.
+func inBounds(i, n int) bool {
+	return i >= 0 && i < n
+}
+
+// messageFullName walks idxs through fd's MessageType/NestedType chain and
+// returns the full name of the message it points to.
+func messageFullName(fd *descriptorpb.FileDescriptorProto, idxs []int) (protoreflect.FullName, error) {
+	if len(idxs) == 0 || !inBounds(idxs[0], len(fd.GetMessageType())) {
+		return "", fmt.Errorf("descriptor: invalid message index path %v", idxs)
+	}
+	md := fd.MessageType[idxs[0]]
+	name := md.GetName()
+	for _, i := range idxs[1:] {
+		if !inBounds(i, len(md.GetNestedType())) {
+			return "", fmt.Errorf("descriptor: invalid message index path %v", idxs)
+		}
+		md = md.NestedType[i]
+		name += "." + md.GetName()
+	}
+	return protoreflect.FullName(joinPackage(fd.GetPackage(), name)), nil
+}
+
+// enumFullName walks idxs through fd's MessageType/NestedType/EnumType chain
+// and returns the full name of the enum it points to.
+func enumFullName(fd *descriptorpb.FileDescriptorProto, idxs []int) (protoreflect.FullName, error) {
+	if len(idxs) == 0 {
+		return "", fmt.Errorf("descriptor: invalid enum index path %v", idxs)
+	}
+	if len(idxs) == 1 {
+		if !inBounds(idxs[0], len(fd.GetEnumType())) {
+			return "", fmt.Errorf("descriptor: invalid enum index path %v", idxs)
+		}
+		ed := fd.EnumType[idxs[0]]
+		return protoreflect.FullName(joinPackage(fd.GetPackage(), ed.GetName())), nil
+	}
+	if !inBounds(idxs[0], len(fd.GetMessageType())) {
+		return "", fmt.Errorf("descriptor: invalid enum index path %v", idxs)
+	}
+	md := fd.MessageType[idxs[0]]
+	name := md.GetName()
+	for _, i := range idxs[1 : len(idxs)-1] {
+		if !inBounds(i, len(md.GetNestedType())) {
+			return "", fmt.Errorf("descriptor: invalid enum index path %v", idxs)
+		}
+		md = md.NestedType[i]
+		name += "." + md.GetName()
+	}
+	last := idxs[len(idxs)-1]
+	if !inBounds(last, len(md.GetEnumType())) {
+		return "", fmt.Errorf("descriptor: invalid enum index path %v", idxs)
+	}
+	name += "." + md.EnumType[last].GetName()
+	return protoreflect.FullName(joinPackage(fd.GetPackage(), name)), nil
+}
+
+func joinPackage(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
@@ -0,0 +1,37 @@
+package descriptor
+
+import (
+	"testing"
+
+	"github.com/whiteCcinn/protobuf-go/reflect/protoreflect"
+	"github.com/whiteCcinn/protobuf-go/types/dynamicpb"
+	"github.com/whiteCcinn/protobuf-go/types/known/wrapperspb"
+)
+
+// noParentDescriptor wraps a protoreflect.MessageDescriptor and hides its
+// parent, simulating a message descriptor that was dynamically constructed
+// rather than loaded from a generated .pb.go file.
+type noParentDescriptor struct {
+	protoreflect.MessageDescriptor
+}
+
+func (noParentDescriptor) Parent() protoreflect.Descriptor { return nil }
+
+func TestMessageDescriptorProtoStandalone(t *testing.T) {
+	md := noParentDescriptor{wrapperspb.File_google_protobuf_wrappers_proto.Messages().ByName("StringValue")}
+	m := dynamicpb.NewMessage(md)
+
+	fd, dp := MessageDescriptorProto(m)
+	if fd == nil || dp == nil {
+		t.Fatalf("MessageDescriptorProto(dynamicpb message) = (%v, %v), want both non-nil", fd, dp)
+	}
+	if got, want := dp.GetName(), "StringValue"; got != want {
+		t.Errorf("message name = %q, want %q", got, want)
+	}
+	if got, want := fd.GetName(), "synthetic/google.protobuf.StringValue.proto"; got != want {
+		t.Errorf("synthetic file name = %q, want %q", got, want)
+	}
+	if got := fd.GetSyntax(); got != "proto3" {
+		t.Errorf("synthetic file syntax = %q, want %q", got, "proto3")
+	}
+}
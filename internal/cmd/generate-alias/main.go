@@ -7,6 +7,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -19,8 +20,10 @@ import (
 	"github.com/golang/protobuf/proto"
 	gengo "github.com/whiteCcinn/protobuf-go/cmd/protoc-gen-go/internal_gengo"
 	"github.com/whiteCcinn/protobuf-go/compiler/protogen"
+	"github.com/whiteCcinn/protobuf-go/compiler/protolint"
 	"github.com/whiteCcinn/protobuf-go/reflect/protodesc"
 	"github.com/whiteCcinn/protobuf-go/reflect/protoreflect"
+	"github.com/whiteCcinn/protobuf-go/reflect/protoregistry"
 
 	"github.com/whiteCcinn/protobuf-go/types/descriptorpb"
 	"github.com/whiteCcinn/protobuf-go/types/known/anypb"
@@ -32,54 +35,101 @@ import (
 	"github.com/whiteCcinn/protobuf-go/types/pluginpb"
 )
 
+// forwardPackage describes a single v1 package that should be regenerated
+// as a thin "public import" shim of a v2 package.
+type forwardPackage struct {
+	oldGoPkg string
+	newGoPkg string
+	pbDesc   protoreflect.FileDescriptor
+}
+
+// config is the schema accepted by the -config flag, as a JSON document. It
+// lets callers outside this repo reuse the shim trick for their own vendored
+// v1 packages without forking the generator. JSON rather than YAML is all
+// this module takes on, to avoid pulling in a third-party dependency for it.
+type config struct {
+	Packages []struct {
+		// OldGoPackage is the import path of the legacy v1 package to
+		// regenerate, optionally suffixed with ";pkgname" (the form Go
+		// import comments use) when the package name doesn't match the
+		// last element of the import path.
+		OldGoPackage    string `json:"old_go_package"`
+		NewGoPackage    string `json:"new_go_package"`
+		ProtoImportPath string `json:"proto_import_path"`
+	} `json:"packages"`
+
+	// ExtraDescriptorSets lists paths to serialized FileDescriptorSet
+	// protos to register into protoregistry.GlobalFiles before resolving
+	// Packages, so that ProtoImportPath can reference proto files that
+	// are not already linked into this binary as compiled-in Go packages.
+	ExtraDescriptorSets []string `json:"extra_descriptor_sets"`
+}
+
+// defaultPackages is the built-in set of golang/protobuf packages that this
+// repository itself forwards to their v2 equivalents. It is used whenever
+// -config is not specified, preserving the historical go:generate behavior.
+var defaultPackages = []forwardPackage{{
+	oldGoPkg: "github.com/golang/protobuf/protoc-gen-go/descriptor;descriptor",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/descriptorpb",
+	pbDesc:   descriptorpb.File_google_protobuf_descriptor_proto,
+}, {
+	oldGoPkg: "github.com/golang/protobuf/protoc-gen-go/plugin;plugin_go",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/pluginpb",
+	pbDesc:   pluginpb.File_google_protobuf_compiler_plugin_proto,
+}, {
+	oldGoPkg: "github.com/golang/protobuf/ptypes/any;any",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/anypb",
+	pbDesc:   anypb.File_google_protobuf_any_proto,
+}, {
+	oldGoPkg: "github.com/golang/protobuf/ptypes/duration;duration",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/durationpb",
+	pbDesc:   durationpb.File_google_protobuf_duration_proto,
+}, {
+	oldGoPkg: "github.com/golang/protobuf/ptypes/timestamp;timestamp",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/timestamppb",
+	pbDesc:   timestamppb.File_google_protobuf_timestamp_proto,
+}, {
+	oldGoPkg: "github.com/golang/protobuf/ptypes/wrappers;wrappers",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/wrapperspb",
+	pbDesc:   wrapperspb.File_google_protobuf_wrappers_proto,
+}, {
+	oldGoPkg: "github.com/golang/protobuf/ptypes/struct;structpb",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/structpb",
+	pbDesc:   structpb.File_google_protobuf_struct_proto,
+}, {
+	oldGoPkg: "github.com/golang/protobuf/ptypes/empty;empty",
+	newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/emptypb",
+	pbDesc:   emptypb.File_google_protobuf_empty_proto,
+}}
+
 func main() {
 	run := flag.Bool("execute", false, "Write generated files to destination.")
+	configPath := flag.String("config", "", "Path to a JSON file listing the packages to forward. If unset, the built-in golang/protobuf package set is used.")
+	outputRoot := flag.String("output_root", "github.com/golang/protobuf", "Import path that generated file names are made relative to before being written to disk.")
+	lint := flag.Bool("lint", false, "Run the default protolint rule set over each forwarded file before generating it.")
+	lintFailOn := flag.Int("lint_fail_on", 1, "Fail generation once this many lint diagnostics have been reported. Only consulted when -lint is set.")
 	flag.Parse()
 
-	// Set of generated proto packages to forward to v2.
-	files := []struct {
-		oldGoPkg string
-		newGoPkg string
-		pbDesc   protoreflect.FileDescriptor
-	}{{
-		oldGoPkg: "github.com/golang/protobuf/protoc-gen-go/descriptor;descriptor",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/descriptorpb",
-		pbDesc:   descriptorpb.File_google_protobuf_descriptor_proto,
-	}, {
-		oldGoPkg: "github.com/golang/protobuf/protoc-gen-go/plugin;plugin_go",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/pluginpb",
-		pbDesc:   pluginpb.File_google_protobuf_compiler_plugin_proto,
-	}, {
-		oldGoPkg: "github.com/golang/protobuf/ptypes/any;any",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/anypb",
-		pbDesc:   anypb.File_google_protobuf_any_proto,
-	}, {
-		oldGoPkg: "github.com/golang/protobuf/ptypes/duration;duration",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/durationpb",
-		pbDesc:   durationpb.File_google_protobuf_duration_proto,
-	}, {
-		oldGoPkg: "github.com/golang/protobuf/ptypes/timestamp;timestamp",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/timestamppb",
-		pbDesc:   timestamppb.File_google_protobuf_timestamp_proto,
-	}, {
-		oldGoPkg: "github.com/golang/protobuf/ptypes/wrappers;wrappers",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/wrapperspb",
-		pbDesc:   wrapperspb.File_google_protobuf_wrappers_proto,
-	}, {
-		oldGoPkg: "github.com/golang/protobuf/ptypes/struct;structpb",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/structpb",
-		pbDesc:   structpb.File_google_protobuf_struct_proto,
-	}, {
-		oldGoPkg: "github.com/golang/protobuf/ptypes/empty;empty",
-		newGoPkg: "github.com/whiteCcinn/protobuf-go/types/known/emptypb",
-		pbDesc:   emptypb.File_google_protobuf_empty_proto,
-	}}
+	files := defaultPackages
+	if *configPath != "" {
+		var err error
+		files, err = loadConfiguredPackages(*configPath)
+		check(err)
+	}
 
 	// For each package, construct a proto file that public imports the package.
 	var req pluginpb.CodeGeneratorRequest
 	var flags []string
+	newGoPkgByPath := make(map[string]string)
 	for _, file := range files {
-		pkgPath := file.oldGoPkg[:strings.IndexByte(file.oldGoPkg, ';')]
+		// oldGoPkg is an import path, optionally followed by ";pkgname" when
+		// the package name doesn't match the last path element (the form Go
+		// import comments use). -config entries aren't required to include
+		// the ";pkgname" suffix, so only split on ';' when it's present.
+		pkgPath := file.oldGoPkg
+		if i := strings.IndexByte(pkgPath, ';'); i >= 0 {
+			pkgPath = pkgPath[:i]
+		}
 		fd := &descriptorpb.FileDescriptorProto{
 			Name:             proto.String(pkgPath + "/" + path.Base(pkgPath) + ".proto"),
 			Syntax:           proto.String(file.pbDesc.Syntax().String()),
@@ -90,16 +140,36 @@ func main() {
 		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(file.pbDesc), fd)
 		req.FileToGenerate = append(req.FileToGenerate, fd.GetName())
 		flags = append(flags, "M"+file.pbDesc.Path()+"="+file.newGoPkg)
+		newGoPkgByPath[fd.GetName()] = file.newGoPkg
 	}
 	req.Parameter = proto.String(strings.Join(flags, ","))
 
 	// Use the internal logic of protoc-gen-go to generate the files.
 	gen, err := protogen.Options{}.New(&req)
 	check(err)
+	if *lint {
+		linter := protolint.New(protolint.DefaultRules()...)
+		var diags []protolint.Diagnostic
+		for _, file := range gen.Files {
+			if file.Generate {
+				diags = append(diags, linter.Lint([]*protogen.File{file})...)
+			}
+		}
+		for _, d := range diags {
+			fmt.Fprintln(os.Stderr, d)
+		}
+		if len(diags) >= *lintFailOn {
+			gen.Error(fmt.Errorf("protolint: %d diagnostic(s) reported, threshold is %d", len(diags), *lintFailOn))
+		}
+	}
 	for _, file := range gen.Files {
-		if file.Generate {
-			gengo.GenerateVersionMarkers = false
-			gengo.GenerateFile(gen, file)
+		if !file.Generate {
+			continue
+		}
+		gengo.GenerateVersionMarkers = false
+		gengo.GenerateFile(gen, file)
+		if len(file.Proto.GetService()) > 0 {
+			forwardServiceDescs(gen, file, newGoPkgByPath[file.Desc.Path()])
 		}
 	}
 
@@ -109,7 +179,7 @@ func main() {
 		panic("gengo error: " + resp.GetError())
 	}
 	for _, file := range resp.File {
-		relPath, err := filepath.Rel(filepath.FromSlash("github.com/golang/protobuf"), file.GetName())
+		relPath, err := filepath.Rel(filepath.FromSlash(*outputRoot), file.GetName())
 		check(err)
 
 		check(ioutil.WriteFile(relPath+".bak", []byte(file.GetContent()), 0664))
@@ -125,6 +195,84 @@ func main() {
 	}
 }
 
+// loadConfiguredPackages reads and validates a -config file, registers any
+// extra descriptor sets it references into protoregistry.GlobalFiles, and
+// resolves each listed package's ProtoImportPath to a protoreflect.FileDescriptor.
+func loadConfiguredPackages(path string) ([]forwardPackage, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %v", err)
+	}
+	var c config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("parse config %q: %v", path, err)
+	}
+
+	for _, descSetPath := range c.ExtraDescriptorSets {
+		if err := registerDescriptorSet(descSetPath); err != nil {
+			return nil, fmt.Errorf("register descriptor set %q: %v", descSetPath, err)
+		}
+	}
+
+	files := make([]forwardPackage, len(c.Packages))
+	for i, p := range c.Packages {
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(p.ProtoImportPath)
+		if err != nil {
+			return nil, fmt.Errorf("resolve proto_import_path %q for %q: %v", p.ProtoImportPath, p.OldGoPackage, err)
+		}
+		files[i] = forwardPackage{oldGoPkg: p.OldGoPackage, newGoPkg: p.NewGoPackage, pbDesc: fd}
+	}
+	return files, nil
+}
+
+// registerDescriptorSet parses a serialized descriptorpb.FileDescriptorSet
+// from disk and registers each of its files into protoregistry.GlobalFiles,
+// so that later lookups by ProtoImportPath can find proto packages that are
+// not compiled into this binary as generated Go code.
+func registerDescriptorSet(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return err
+	}
+	for _, fdProto := range set.File {
+		fd, err := protodesc.NewFile(fdProto, protoregistry.GlobalFiles)
+		if err != nil {
+			return fmt.Errorf("build file %q: %v", fdProto.GetName(), err)
+		}
+		if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+			return fmt.Errorf("register file %q: %v", fdProto.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// forwardServiceDescs writes a "<prefix>_grpc.pb.go" file that forwards the
+// grpc.ServiceDesc variable of every service declared in file to the
+// corresponding variable in newGoPkg, the same way the message shim forwards
+// message and enum types via a public import.
+func forwardServiceDescs(gen *protogen.Plugin, file *protogen.File, newGoPkg string) {
+	services := file.Proto.GetService()
+	if len(services) == 0 || newGoPkg == "" {
+		return
+	}
+	newPkg := protogen.GoImportPath(newGoPkg)
+
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+"_grpc.pb.go", file.GoImportPath)
+	g.P("// Code generated by generate-alias. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+	for _, svc := range services {
+		name := svc.GetName() + "_ServiceDesc"
+		g.P("var ", name, " = ", g.QualifiedGoIdent(newPkg.Ident(name)))
+	}
+}
+
 func check(err error) {
 	if err != nil {
 		panic(err)